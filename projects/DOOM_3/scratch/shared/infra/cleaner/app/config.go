@@ -0,0 +1,135 @@
+package main
+
+import (
+    "fmt"
+    "log/slog"
+    "sync/atomic"
+    "time"
+
+    "github.com/fsnotify/fsnotify"
+    "github.com/spf13/viper"
+)
+
+// CleanupProfile is a named, pre-approved cleanup target. Profiles are the
+// normal way to trigger a cleanup; an inline CleanupSpec is for ad-hoc runs.
+type CleanupProfile struct {
+    Name           string   `mapstructure:"name"`
+    Paths          []string `mapstructure:"paths"`
+    IncludeGlobs   []string `mapstructure:"include_globs"`
+    ExcludeGlobs   []string `mapstructure:"exclude_globs"`
+    MinAge         string   `mapstructure:"min_age"`
+    MaxDepth       int      `mapstructure:"max_depth"`
+    KeepLastN      int      `mapstructure:"keep_last_n"`
+    FollowSymlinks bool     `mapstructure:"follow_symlinks"`
+    PreserveOwners bool     `mapstructure:"preserve_owners"`
+}
+
+// Config is the top-level cleanup configuration: a set of named profiles
+// that POST /cleanup can reference by name.
+type Config struct {
+    Profiles []CleanupProfile `mapstructure:"profiles"`
+}
+
+func (c *Config) profileByName(name string) (CleanupProfile, bool) {
+    for _, p := range c.Profiles {
+        if p.Name == name {
+            return p, true
+        }
+    }
+    return CleanupProfile{}, false
+}
+
+// ConfigManager loads cleanup profiles from YAML (with env var overrides)
+// and hot-reloads them when the file changes on disk. A failed reload logs
+// and keeps serving the last-known-good config.
+type ConfigManager struct {
+    v       *viper.Viper
+    current atomic.Pointer[Config]
+    logger  *slog.Logger
+}
+
+// NewConfigManager loads path and starts watching it for changes. It
+// returns an error only if the initial load fails - there is no "old"
+// config to fall back to yet.
+func NewConfigManager(path string, logger *slog.Logger) (*ConfigManager, error) {
+    v := viper.New()
+    v.SetConfigFile(path)
+    v.SetEnvPrefix("CLEANUP")
+    v.AutomaticEnv()
+
+    cm := &ConfigManager{v: v, logger: logger}
+    if err := cm.reload(); err != nil {
+        return nil, fmt.Errorf("loading config %s: %w", path, err)
+    }
+
+    v.OnConfigChange(func(_ fsnotify.Event) {
+        if err := cm.reload(); err != nil {
+            logger.Error("config reload failed, keeping previous config", "error", err)
+            return
+        }
+        logger.Info("config reloaded", "path", path)
+    })
+    v.WatchConfig()
+
+    return cm, nil
+}
+
+func (cm *ConfigManager) reload() error {
+    if err := cm.v.ReadInConfig(); err != nil {
+        return err
+    }
+    var cfg Config
+    if err := cm.v.Unmarshal(&cfg); err != nil {
+        return err
+    }
+    if err := validateConfig(&cfg); err != nil {
+        return err
+    }
+    cm.current.Store(&cfg)
+    return nil
+}
+
+// validateConfig rejects a config whose profiles the runner couldn't safely
+// execute, so a bad reload is dropped and the last-known-good config keeps
+// serving instead of silently falling back to "no filters applied".
+func validateConfig(cfg *Config) error {
+    for _, p := range cfg.Profiles {
+        if len(p.Paths) == 0 {
+            return fmt.Errorf("profile %q: paths must not be empty", p.Name)
+        }
+        if len(p.IncludeGlobs) == 0 {
+            return fmt.Errorf("profile %q: include_globs must not be empty", p.Name)
+        }
+        if p.MinAge != "" {
+            if _, err := time.ParseDuration(p.MinAge); err != nil {
+                return fmt.Errorf("profile %q: invalid min_age %q: %w", p.Name, p.MinAge, err)
+            }
+        }
+    }
+    return nil
+}
+
+// Profile looks up a named cleanup profile from the currently loaded config.
+func (cm *ConfigManager) Profile(name string) (CleanupProfile, bool) {
+    cfg := cm.current.Load()
+    if cfg == nil {
+        return CleanupProfile{}, false
+    }
+    return cfg.profileByName(name)
+}
+
+// specFromProfile converts a named profile into the CleanupSpec shape the
+// job runner understands. PreserveOwners has no field here: the runner only
+// ever os.Remove's matched files and never touches ownership of anything
+// else, so the property holds automatically and there is nothing to wire up.
+func specFromProfile(p CleanupProfile) CleanupSpec {
+    return CleanupSpec{
+        Dirs:           p.Paths,
+        IncludeGlobs:   p.IncludeGlobs,
+        ExcludeGlobs:   p.ExcludeGlobs,
+        MaxAge:         p.MinAge,
+        MaxDepth:       p.MaxDepth,
+        KeepLastN:      p.KeepLastN,
+        FollowSymlinks: p.FollowSymlinks,
+    }
+}
@@ -0,0 +1,554 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    "sync/atomic"
+    "time"
+
+    "github.com/google/uuid"
+    "go.opentelemetry.io/otel/attribute"
+    "go.opentelemetry.io/otel/trace"
+)
+
+// JobStatus is the lifecycle state of a cleanup job.
+type JobStatus string
+
+const (
+    JobPending   JobStatus = "pending"
+    JobRunning   JobStatus = "running"
+    JobSucceeded JobStatus = "succeeded"
+    JobFailed    JobStatus = "failed"
+    JobCancelled JobStatus = "cancelled"
+)
+
+// CleanupSpec describes what a cleanup job should purge. MaxAge is a
+// time.ParseDuration string (e.g. "24h"); files modified more recently than
+// that are left alone. MaxDepth and KeepLastN are zero-value-means-off: 0
+// means "no depth limit" / "don't keep a minimum number of matches".
+type CleanupSpec struct {
+    Profile        string   `json:"profile,omitempty"`
+    Dirs           []string `json:"dirs"`
+    IncludeGlobs   []string `json:"include_globs"`
+    ExcludeGlobs   []string `json:"exclude_globs,omitempty"`
+    MaxAge         string   `json:"max_age"`
+    MaxDepth       int      `json:"max_depth,omitempty"`
+    KeepLastN      int      `json:"keep_last_n,omitempty"`
+    FollowSymlinks bool     `json:"follow_symlinks,omitempty"`
+    DryRun         bool     `json:"dry_run"`
+}
+
+// Job tracks the state of a single cleanup run.
+type Job struct {
+    ID           string      `json:"id"`
+    Spec         CleanupSpec `json:"spec"`
+    Status       JobStatus   `json:"status"`
+    StartedAt    *time.Time  `json:"started_at,omitempty"`
+    FinishedAt   *time.Time  `json:"finished_at,omitempty"`
+    BytesFreed   int64       `json:"bytes_freed"`
+    FilesRemoved int         `json:"files_removed"`
+    Error        string      `json:"error,omitempty"`
+
+    rootCtx context.Context
+    cancel  context.CancelFunc
+}
+
+// clone returns a shallow copy of the job. The worker mutates its own clone
+// as a job progresses and stores a fresh clone at each transition, so a
+// pointer handed out by the store is never mutated after the fact.
+func (j *Job) clone() *Job {
+    c := *j
+    return &c
+}
+
+// JobStore persists job state. An in-memory implementation is provided here;
+// a disk-backed store can satisfy the same interface later.
+type JobStore interface {
+    Put(job *Job)
+    Get(id string) (*Job, bool)
+    List() []*Job
+}
+
+// MemoryJobStore is a JobStore backed by a guarded in-memory map.
+type MemoryJobStore struct {
+    mu   sync.RWMutex
+    jobs map[string]*Job
+}
+
+func NewMemoryJobStore() *MemoryJobStore {
+    return &MemoryJobStore{jobs: make(map[string]*Job)}
+}
+
+func (s *MemoryJobStore) Put(job *Job) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.jobs[job.ID] = job
+}
+
+// Get returns a copy of the job so the caller can never observe or race
+// with the worker goroutine still mutating its own in-progress copy.
+func (s *MemoryJobStore) Get(id string) (*Job, bool) {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    j, ok := s.jobs[id]
+    if !ok {
+        return nil, false
+    }
+    return j.clone(), true
+}
+
+// List returns copies of all known jobs; see Get.
+func (s *MemoryJobStore) List() []*Job {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    out := make([]*Job, 0, len(s.jobs))
+    for _, j := range s.jobs {
+        out = append(out, j.clone())
+    }
+    return out
+}
+
+// CleanupService runs cleanup jobs against a worker pool with a fixed
+// concurrency cap so a burst of requests can't fork-bomb the filesystem.
+type CleanupService struct {
+    store     JobStore
+    work      chan *Job
+    wg        sync.WaitGroup
+    accepting atomic.Bool
+    config    *ConfigManager
+
+    // closeMu serializes StopAccepting's close(work) against Enqueue's
+    // check-then-send so a send can never land on a closed channel.
+    closeMu sync.Mutex
+}
+
+func NewCleanupService(store JobStore, concurrency int, config *ConfigManager) *CleanupService {
+    if concurrency < 1 {
+        concurrency = 1
+    }
+    svc := &CleanupService{
+        store:  store,
+        work:   make(chan *Job, concurrency*4),
+        config: config,
+    }
+    svc.accepting.Store(true)
+    for i := 0; i < concurrency; i++ {
+        svc.wg.Add(1)
+        go svc.worker()
+    }
+    return svc
+}
+
+// StopAccepting stops taking new jobs and closes the work queue so idle
+// workers exit once pending jobs drain.
+func (svc *CleanupService) StopAccepting() {
+    svc.closeMu.Lock()
+    defer svc.closeMu.Unlock()
+    if svc.accepting.CompareAndSwap(true, false) {
+        close(svc.work)
+    }
+}
+
+// Drain waits for in-flight jobs to finish, cancelling any still running
+// once ctx's deadline passes.
+func (svc *CleanupService) Drain(ctx context.Context) {
+    done := make(chan struct{})
+    go func() {
+        svc.wg.Wait()
+        close(done)
+    }()
+
+    select {
+    case <-done:
+    case <-ctx.Done():
+        for _, job := range svc.store.List() {
+            if (job.Status == JobRunning || job.Status == JobPending) && job.cancel != nil {
+                job.cancel()
+            }
+        }
+        <-done
+    }
+}
+
+func (svc *CleanupService) worker() {
+    defer svc.wg.Done()
+    for job := range svc.work {
+        cleanupQueueDepth.Set(float64(len(svc.work)))
+        svc.run(job)
+    }
+}
+
+func (svc *CleanupService) run(enqueued *Job) {
+    // Work on our own copy from here on; enqueued may still be referenced by
+    // a caller that read it straight off the Enqueue return value.
+    job := enqueued.clone()
+
+    // job.rootCtx is already cancelable - cancel was captured at Enqueue
+    // time so a still-pending job can be cancelled before a worker ever
+    // picks it up. Don't wrap it in a second context.WithCancel here, or
+    // that earlier cancel stops being the one the job actually runs under.
+    ctx, jobSpan := tracer.Start(job.rootCtx, "cleanup.job", trace.WithAttributes(jobSpanAttributes(job.Spec)...))
+    defer jobSpan.End()
+
+    now := time.Now()
+    job.StartedAt = &now
+    job.Status = JobRunning
+    svc.store.Put(job.clone())
+    cleanupInFlight.Inc()
+    defer cleanupInFlight.Dec()
+
+    ageCutoff, hasAgeCutoff := ageCutoffFor(job.Spec.MaxAge)
+
+    var candidates []cleanupCandidate
+    visitedSymlinks := map[string]bool{}
+
+    for _, dir := range job.Spec.Dirs {
+        select {
+        case <-ctx.Done():
+            job.Status = JobCancelled
+            job.Error = ctx.Err().Error()
+            svc.finish(job, now)
+            return
+        default:
+        }
+
+        _, walkSpan := tracer.Start(ctx, "cleanup.walk", trace.WithAttributes(
+            attribute.String("cleanup.path", dir),
+            attribute.Bool("cleanup.dry_run", job.Spec.DryRun),
+        ))
+        found, err := collectCandidates(ctx, dir, dir, job.Spec, ageCutoff, hasAgeCutoff, visitedSymlinks)
+        walkSpan.End()
+        if err != nil {
+            if ctx.Err() != nil {
+                job.Status = JobCancelled
+                job.Error = ctx.Err().Error()
+            } else {
+                job.Status = JobFailed
+                job.Error = err.Error()
+            }
+            svc.finish(job, now)
+            return
+        }
+        candidates = append(candidates, found...)
+    }
+
+    candidates = applyKeepLastN(candidates, job.Spec.KeepLastN)
+
+    var bytesFreed int64
+    var filesRemoved int
+    for _, c := range candidates {
+        select {
+        case <-ctx.Done():
+            job.BytesFreed = bytesFreed
+            job.FilesRemoved = filesRemoved
+            job.Status = JobCancelled
+            job.Error = ctx.Err().Error()
+            svc.finish(job, now)
+            return
+        default:
+        }
+        if job.Spec.DryRun {
+            bytesFreed += c.info.Size()
+            filesRemoved++
+            continue
+        }
+        if rmErr := os.Remove(c.path); rmErr == nil {
+            bytesFreed += c.info.Size()
+            filesRemoved++
+        }
+    }
+
+    job.BytesFreed = bytesFreed
+    job.FilesRemoved = filesRemoved
+    job.Status = JobSucceeded
+    svc.finish(job, now)
+}
+
+// cleanupCandidate is a file that matched a spec's filters and is eligible
+// for removal, pending the KeepLastN trim.
+type cleanupCandidate struct {
+    path string
+    info os.FileInfo
+}
+
+func ageCutoffFor(maxAge string) (time.Time, bool) {
+    if maxAge == "" {
+        return time.Time{}, false
+    }
+    d, err := time.ParseDuration(maxAge)
+    if err != nil {
+        return time.Time{}, false
+    }
+    return time.Now().Add(-d), true
+}
+
+// collectCandidates walks root (a descendant of dir, initially dir itself)
+// and returns every file that passes the include/exclude globs, the age
+// cutoff, and MaxDepth relative to dir. Symlinks (to directories or files)
+// are only followed when spec.FollowSymlinks is set; visited de-dupes resolved
+// targets across the whole job so a symlink cycle can't loop forever. The
+// walk bails out with ctx.Err() as soon as ctx is cancelled, so a large tree
+// doesn't keep a job running past its deadline.
+func collectCandidates(ctx context.Context, dir, root string, spec CleanupSpec, ageCutoff time.Time, hasAgeCutoff bool, visited map[string]bool) ([]cleanupCandidate, error) {
+    var out []cleanupCandidate
+    err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+        if ctxErr := ctx.Err(); ctxErr != nil {
+            return ctxErr
+        }
+        if err != nil {
+            return nil
+        }
+        if info.Mode()&os.ModeSymlink != 0 {
+            return followSymlink(ctx, dir, path, spec, ageCutoff, hasAgeCutoff, visited, &out)
+        }
+        if info.IsDir() {
+            if path == root {
+                return nil
+            }
+            if spec.MaxDepth > 0 && depthOf(dir, path) > spec.MaxDepth {
+                return filepath.SkipDir
+            }
+            return nil
+        }
+        if spec.MaxDepth > 0 && depthOf(dir, path) > spec.MaxDepth {
+            return nil
+        }
+        if eligible(path, info, spec, ageCutoff, hasAgeCutoff) {
+            out = append(out, cleanupCandidate{path: path, info: info})
+        }
+        return nil
+    })
+    return out, err
+}
+
+// followSymlink does nothing unless FollowSymlinks is set. When it is, it
+// resolves path (a symlink) and, if it points at a directory, recurses into
+// it; if it points at a file, the file itself is considered as a candidate
+// under its symlink path.
+func followSymlink(ctx context.Context, dir, path string, spec CleanupSpec, ageCutoff time.Time, hasAgeCutoff bool, visited map[string]bool, out *[]cleanupCandidate) error {
+    if !spec.FollowSymlinks {
+        return nil
+    }
+    target, err := filepath.EvalSymlinks(path)
+    if err != nil || visited[target] {
+        return nil
+    }
+    visited[target] = true
+
+    targetInfo, err := os.Stat(target)
+    if err != nil {
+        return nil
+    }
+    if targetInfo.IsDir() {
+        nested, err := collectCandidates(ctx, dir, target, spec, ageCutoff, hasAgeCutoff, visited)
+        if err != nil {
+            // Propagate a cancellation so the outer walk stops too; any
+            // other nested-walk error is swallowed, same as before.
+            if ctx.Err() != nil {
+                return err
+            }
+            return nil
+        }
+        *out = append(*out, nested...)
+        return nil
+    }
+    if eligible(path, targetInfo, spec, ageCutoff, hasAgeCutoff) {
+        *out = append(*out, cleanupCandidate{path: path, info: targetInfo})
+    }
+    return nil
+}
+
+// depthOf returns how many directories separate path from dir (dir's direct
+// children are depth 1).
+func depthOf(dir, path string) int {
+    rel, err := filepath.Rel(dir, path)
+    if err != nil || rel == "." {
+        return 0
+    }
+    return strings.Count(rel, string(os.PathSeparator)) + 1
+}
+
+func eligible(path string, info os.FileInfo, spec CleanupSpec, ageCutoff time.Time, hasAgeCutoff bool) bool {
+    name := filepath.Base(path)
+    if !matchesAny(spec.IncludeGlobs, name) {
+        return false
+    }
+    if matchesAnyOf(spec.ExcludeGlobs, name) {
+        return false
+    }
+    if hasAgeCutoff && info.ModTime().After(ageCutoff) {
+        return false
+    }
+    return true
+}
+
+// applyKeepLastN keeps the KeepLastN most recently modified candidates
+// (they are not eligible for removal) and returns the rest. KeepLastN <= 0
+// means the knob is off and every candidate stays eligible.
+func applyKeepLastN(candidates []cleanupCandidate, keepLastN int) []cleanupCandidate {
+    if keepLastN <= 0 {
+        return candidates
+    }
+    if len(candidates) <= keepLastN {
+        return nil
+    }
+    sorted := append([]cleanupCandidate(nil), candidates...)
+    sort.Slice(sorted, func(i, j int) bool {
+        return sorted[i].info.ModTime().After(sorted[j].info.ModTime())
+    })
+    return sorted[keepLastN:]
+}
+
+// finish stamps a job's end time and reports its outcome to the metrics
+// registry.
+func (svc *CleanupService) finish(job *Job, started time.Time) {
+    finished := time.Now()
+    job.FinishedAt = &finished
+    svc.store.Put(job.clone())
+
+    cleanupJobsTotal.WithLabelValues(string(job.Status)).Inc()
+    cleanupJobDuration.Observe(finished.Sub(started).Seconds())
+    if !job.Spec.DryRun {
+        cleanupFilesRemovedTotal.Add(float64(job.FilesRemoved))
+        cleanupBytesFreedTotal.Add(float64(job.BytesFreed))
+    }
+}
+
+// matchesAny reports whether name matches one of globs; an empty glob list
+// matches everything (used for "include all by default").
+func matchesAny(globs []string, name string) bool {
+    if len(globs) == 0 {
+        return true
+    }
+    return matchesAnyOf(globs, name)
+}
+
+// matchesAnyOf reports whether name matches one of globs; an empty glob
+// list matches nothing (used for "exclude nothing by default").
+func matchesAnyOf(globs []string, name string) bool {
+    for _, g := range globs {
+        if ok, _ := filepath.Match(g, name); ok {
+            return true
+        }
+    }
+    return false
+}
+
+// Enqueue schedules a new cleanup job, or returns nil if the service is no
+// longer accepting work (e.g. during shutdown). ctx is used only to capture
+// the caller's trace context for span propagation into the async job. The
+// job's CancelFunc is created here, not when a worker picks it up, so a
+// still-queued job can be cancelled too.
+func (svc *CleanupService) Enqueue(ctx context.Context, spec CleanupSpec) *Job {
+    svc.closeMu.Lock()
+    defer svc.closeMu.Unlock()
+    if !svc.accepting.Load() {
+        return nil
+    }
+    rootCtx, cancel := context.WithCancel(detachedRootContext(ctx))
+    job := &Job{
+        ID:      uuid.New().String(),
+        Spec:    spec,
+        Status:  JobPending,
+        rootCtx: rootCtx,
+        cancel:  cancel,
+    }
+    svc.store.Put(job)
+    svc.work <- job
+    cleanupQueueDepth.Set(float64(len(svc.work)))
+    return job
+}
+
+func (svc *CleanupService) Cancel(id string) bool {
+    job, ok := svc.store.Get(id)
+    if !ok || job.cancel == nil {
+        return false
+    }
+    job.cancel()
+    return true
+}
+
+// handleCleanupCreate enqueues a new cleanup job and returns 202 Accepted
+// with a Location header pointing at the job's status endpoint.
+func (svc *CleanupService) handleCleanupCreate(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost {
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+    var spec CleanupSpec
+    if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+        http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+        return
+    }
+    if spec.Profile != "" {
+        profile, ok := svc.config.Profile(spec.Profile)
+        if !ok {
+            http.Error(w, fmt.Sprintf("unknown cleanup profile %q", spec.Profile), http.StatusBadRequest)
+            return
+        }
+        dryRun := spec.DryRun
+        spec = specFromProfile(profile)
+        spec.DryRun = dryRun
+    }
+    // Validated after the profile substitution (if any) so a profile's
+    // max_age is checked too, not just an inline spec's.
+    if spec.MaxAge != "" {
+        if _, err := time.ParseDuration(spec.MaxAge); err != nil {
+            http.Error(w, fmt.Sprintf("invalid max_age: %v", err), http.StatusBadRequest)
+            return
+        }
+    }
+
+    ctx, span := tracer.Start(r.Context(), "cleanup.create", trace.WithAttributes(jobSpanAttributes(spec)...))
+    defer span.End()
+
+    job := svc.Enqueue(ctx, spec)
+    if job == nil {
+        http.Error(w, "service is shutting down, not accepting new cleanup jobs", http.StatusServiceUnavailable)
+        return
+    }
+    w.Header().Set("Location", "/cleanup/"+job.ID)
+    w.WriteHeader(http.StatusAccepted)
+    json.NewEncoder(w).Encode(job)
+}
+
+// handleCleanupList lists recent jobs.
+func (svc *CleanupService) handleCleanupList(w http.ResponseWriter, r *http.Request) {
+    jobs := svc.store.List()
+    w.Header().Set("Content-Type", "application/json")
+    json.NewEncoder(w).Encode(jobs)
+}
+
+// handleCleanupItem serves GET (status) and DELETE (cancel) for a single job.
+func (svc *CleanupService) handleCleanupItem(w http.ResponseWriter, r *http.Request) {
+    id := strings.TrimPrefix(r.URL.Path, "/cleanup/")
+    if id == "" {
+        http.NotFound(w, r)
+        return
+    }
+    switch r.Method {
+    case http.MethodGet:
+        job, ok := svc.store.Get(id)
+        if !ok {
+            http.NotFound(w, r)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(job)
+    case http.MethodDelete:
+        if !svc.Cancel(id) {
+            http.NotFound(w, r)
+            return
+        }
+        w.WriteHeader(http.StatusNoContent)
+    default:
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+    }
+}
@@ -1,25 +1,108 @@
 package main
 
 import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "flag"
     "fmt"
     "log"
+    "log/slog"
     "net/http"
+    "os"
+    "time"
+)
+
+// cleanupConcurrency caps how many cleanup jobs can run at once so a burst
+// of requests can't fork-bomb the filesystem.
+const cleanupConcurrency = 4
+
+// failedLoginMax and failedLoginWindow configure the lockout applied to an
+// IP after repeated bad Basic-auth attempts against /cleanup*.
+const (
+    failedLoginMax    = 5
+    failedLoginWindow = 10 * time.Minute
 )
 
 func main() {
-    http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-        w.WriteHeader(http.StatusOK)
-        fmt.Fprintln(w, "healthy")
-    })
+    staticDir := flag.String("static-dir", "", "serve /ui/ assets from this directory instead of the embedded copy")
+    configPath := flag.String("config", "cleanup.yaml", "path to the cleanup profiles config file")
+    flag.Parse()
+
+    logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+    ready := newReadiness()
 
-    http.HandleFunc("/cleanup", func(w http.ResponseWriter, r *http.Request) {
-        // Placeholder: real cleanup logic would go here
-        w.WriteHeader(http.StatusOK)
-        fmt.Fprintln(w, "cleanup started")
+    shutdownTracing, err := initTracing("cleanup-service")
+    if err != nil {
+        log.Fatalf("tracing: %v", err)
+    }
+    defer shutdownTracing(context.Background())
+
+    cfgMgr, err := NewConfigManager(*configPath, logger)
+    if err != nil {
+        log.Fatalf("config: %v", err)
+    }
+
+    svc := NewCleanupService(NewMemoryJobStore(), cleanupConcurrency, cfgMgr)
+
+    authCfg, err := basicAuthConfigFromEnv()
+    if err != nil {
+        log.Fatalf("auth config: %v", err)
+    }
+
+    staticFS, err := uiFS(*staticDir)
+    if err != nil {
+        log.Fatalf("static assets: %v", err)
+    }
+
+    base := NewChain(WithTraceContext, WithRequestID, WithAccessLog(logger), WithMetrics)
+    authOnly := NewChain(WithBasicAuth(authCfg))
+
+    mux := http.NewServeMux()
+    mux.HandleFunc("/health", healthHandler(ready))
+    mux.Handle("/metrics", metricsHandler())
+
+    cleanupMux := http.NewServeMux()
+    cleanupMux.HandleFunc("/cleanup", func(w http.ResponseWriter, r *http.Request) {
+        switch r.Method {
+        case http.MethodPost:
+            svc.handleCleanupCreate(w, r)
+        case http.MethodGet:
+            svc.handleCleanupList(w, r)
+        default:
+            http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        }
     })
+    cleanupMux.HandleFunc("/cleanup/", svc.handleCleanupItem)
+    mux.Handle("/cleanup", authOnly.Then(cleanupMux))
+    mux.Handle("/cleanup/", authOnly.Then(cleanupMux))
+    mountUI(mux, svc, staticFS, authOnly)
 
-    log.Println("Starting CleanUp API on :8080")
-    if err := http.ListenAndServe(":8080", nil); err != nil {
-        log.Fatal(err)
+    srv := &http.Server{
+        Addr:    ":8080",
+        Handler: base.Then(mux),
+    }
+
+    runWithGracefulShutdown(srv, svc, ready, logger)
+}
+
+// basicAuthConfigFromEnv builds the /cleanup* Basic-auth config from
+// CLEANUP_USER and CLEANUP_PASS_SHA256.
+func basicAuthConfigFromEnv() (BasicAuthConfig, error) {
+    user := os.Getenv("CLEANUP_USER")
+    passHashHex := os.Getenv("CLEANUP_PASS_SHA256")
+    if user == "" || passHashHex == "" {
+        return BasicAuthConfig{}, fmt.Errorf("CLEANUP_USER and CLEANUP_PASS_SHA256 must be set")
+    }
+    raw, err := hex.DecodeString(passHashHex)
+    if err != nil || len(raw) != sha256.Size {
+        return BasicAuthConfig{}, fmt.Errorf("CLEANUP_PASS_SHA256 must be a hex-encoded sha256 digest")
     }
+    var hash [32]byte
+    copy(hash[:], raw)
+    return BasicAuthConfig{
+        User:       user,
+        PassSHA256: hash,
+        Tracker:    NewFailedLoginTracker(failedLoginMax, failedLoginWindow),
+    }, nil
 }
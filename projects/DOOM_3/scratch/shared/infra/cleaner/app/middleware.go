@@ -0,0 +1,162 @@
+package main
+
+import (
+    "crypto/sha256"
+    "crypto/subtle"
+    "log/slog"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// Middleware wraps an http.Handler with additional behavior. Chains compose
+// like justinas/alice: Chain(a, b, c).Then(h) runs a, then b, then c, then h.
+type Middleware func(http.Handler) http.Handler
+
+// Chain is an ordered list of Middleware applied outermost-first.
+type Chain []Middleware
+
+func NewChain(mw ...Middleware) Chain {
+    return Chain(mw)
+}
+
+func (c Chain) Then(h http.Handler) http.Handler {
+    for i := len(c) - 1; i >= 0; i-- {
+        h = c[i](h)
+    }
+    return h
+}
+
+type requestIDKey struct{}
+
+// WithRequestID reads X-Request-ID or generates one, and stamps it on the
+// response so callers and logs can correlate.
+func WithRequestID(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        id := r.Header.Get("X-Request-ID")
+        if id == "" {
+            id = uuid.New().String()
+        }
+        w.Header().Set("X-Request-ID", id)
+        ctx := r.Context()
+        r = r.WithContext(contextWithRequestID(ctx, id))
+        next.ServeHTTP(w, r)
+    })
+}
+
+// statusRecorder captures the status code written by the handler so it can
+// be logged after the fact.
+type statusRecorder struct {
+    http.ResponseWriter
+    status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+    sr.status = status
+    sr.ResponseWriter.WriteHeader(status)
+}
+
+// WithAccessLog logs every request as structured JSON via log/slog.
+func WithAccessLog(logger *slog.Logger) Middleware {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            start := time.Now()
+            sr := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+            next.ServeHTTP(sr, r)
+            logger.Info("request",
+                "method", r.Method,
+                "path", r.URL.Path,
+                "status", sr.status,
+                "duration_ms", time.Since(start).Milliseconds(),
+                "remote_addr", r.RemoteAddr,
+                "request_id", requestIDFromContext(r.Context()),
+            )
+        })
+    }
+}
+
+// FailedLoginTracker records failed Basic-auth attempts per remote address
+// and locks an IP out for window once it crosses maxFailures.
+type FailedLoginTracker struct {
+    mu          sync.Mutex
+    failures    map[string][]time.Time
+    maxFailures int
+    window      time.Duration
+}
+
+func NewFailedLoginTracker(maxFailures int, window time.Duration) *FailedLoginTracker {
+    return &FailedLoginTracker{
+        failures:    make(map[string][]time.Time),
+        maxFailures: maxFailures,
+        window:      window,
+    }
+}
+
+func (t *FailedLoginTracker) RecordFailure(addr string) {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    now := time.Now()
+    t.failures[addr] = append(prune(t.failures[addr], now, t.window), now)
+}
+
+func (t *FailedLoginTracker) Locked(addr string) bool {
+    t.mu.Lock()
+    defer t.mu.Unlock()
+    t.failures[addr] = prune(t.failures[addr], time.Now(), t.window)
+    return len(t.failures[addr]) >= t.maxFailures
+}
+
+func prune(times []time.Time, now time.Time, window time.Duration) []time.Time {
+    kept := times[:0]
+    for _, t := range times {
+        if now.Sub(t) < window {
+            kept = append(kept, t)
+        }
+    }
+    return kept
+}
+
+// BasicAuthConfig holds the credentials checked by WithBasicAuth, taken
+// from CLEANUP_USER / CLEANUP_PASS_SHA256.
+type BasicAuthConfig struct {
+    User         string
+    PassSHA256   [32]byte
+    Tracker      *FailedLoginTracker
+}
+
+// WithBasicAuth protects a handler with constant-time HTTP Basic auth and
+// locks out an address after repeated failures.
+func WithBasicAuth(cfg BasicAuthConfig) Middleware {
+    return func(next http.Handler) http.Handler {
+        return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            addr := remoteAddr(r)
+            if cfg.Tracker.Locked(addr) {
+                http.Error(w, "too many failed attempts", http.StatusTooManyRequests)
+                return
+            }
+
+            user, pass, ok := r.BasicAuth()
+            passHash := sha256.Sum256([]byte(pass))
+            userOK := ok && subtle.ConstantTimeCompare([]byte(user), []byte(cfg.User)) == 1
+            passOK := subtle.ConstantTimeCompare(passHash[:], cfg.PassSHA256[:]) == 1
+            if !userOK || !passOK {
+                cfg.Tracker.RecordFailure(addr)
+                w.Header().Set("WWW-Authenticate", `Basic realm="cleanup"`)
+                http.Error(w, "unauthorized", http.StatusUnauthorized)
+                return
+            }
+            next.ServeHTTP(w, r)
+        })
+    }
+}
+
+func remoteAddr(r *http.Request) string {
+    addr := r.RemoteAddr
+    if i := strings.LastIndex(addr, ":"); i != -1 {
+        addr = addr[:i]
+    }
+    return addr
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "log"
+    "log/slog"
+    "net/http"
+    "os"
+    "os/signal"
+    "sync/atomic"
+    "syscall"
+    "time"
+)
+
+// shutdownGraceTimeout bounds how long graceful shutdown waits for in-flight
+// cleanup jobs to reach a safe checkpoint before they are cancelled.
+const shutdownGraceTimeout = 30 * time.Second
+
+// jobStoreSnapshotPath is where the in-memory job store is flushed to on
+// shutdown. Nothing reads it back on startup; a new process starts with an
+// empty store.
+const jobStoreSnapshotPath = "cleanup-jobs.json"
+
+// httpShutdownTimeout bounds srv.Shutdown itself. It is a separate budget
+// from shutdownGraceTimeout so a slow job drain can't eat into the time the
+// listener gets to close in-flight HTTP connections.
+const httpShutdownTimeout = 10 * time.Second
+
+// readiness tracks whether the server is still accepting new work. It flips
+// to false as soon as shutdown begins, before the listener actually stops,
+// so a load balancer can drain traffic ahead of the deadline.
+type readiness struct {
+    ready atomic.Bool
+}
+
+func newReadiness() *readiness {
+    r := &readiness{}
+    r.ready.Store(true)
+    return r
+}
+
+func (r *readiness) setNotReady() { r.ready.Store(false) }
+func (r *readiness) isReady() bool { return r.ready.Load() }
+
+func healthHandler(r *readiness) http.HandlerFunc {
+    return func(w http.ResponseWriter, req *http.Request) {
+        if req.URL.Query().Get("probe") == "ready" && !r.isReady() {
+            http.Error(w, "not ready", http.StatusServiceUnavailable)
+            return
+        }
+        w.WriteHeader(http.StatusOK)
+        w.Write([]byte("healthy\n"))
+    }
+}
+
+// runWithGracefulShutdown starts srv, blocks until SIGINT/SIGTERM, then
+// drains in-flight cleanup jobs within shutdownGraceTimeout and shuts the
+// server down within httpShutdownTimeout.
+func runWithGracefulShutdown(srv *http.Server, svc *CleanupService, r *readiness, logger *slog.Logger) {
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+
+    go func() {
+        log.Printf("Starting CleanUp API on %s", srv.Addr)
+        if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+            log.Fatal(err)
+        }
+    }()
+
+    <-ctx.Done()
+    logger.Info("shutdown signal received, draining")
+    r.setNotReady()
+    svc.StopAccepting()
+
+    drainCtx, cancelDrain := context.WithTimeout(context.Background(), shutdownGraceTimeout)
+    svc.Drain(drainCtx)
+    cancelDrain()
+
+    if ms, ok := svc.store.(*MemoryJobStore); ok {
+        if err := ms.flushToDisk(jobStoreSnapshotPath); err != nil {
+            logger.Error("failed to flush job store", "error", err)
+        }
+    }
+
+    // srv.Shutdown gets its own fresh deadline so a drain that used its full
+    // budget doesn't leave the listener no time to close connections.
+    httpCtx, cancelHTTP := context.WithTimeout(context.Background(), httpShutdownTimeout)
+    defer cancelHTTP()
+    if err := srv.Shutdown(httpCtx); err != nil {
+        logger.Error("graceful shutdown failed", "error", err)
+    }
+}
+
+func (s *MemoryJobStore) flushToDisk(path string) error {
+    s.mu.RLock()
+    defer s.mu.RUnlock()
+    f, err := os.Create(path)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+    return json.NewEncoder(f).Encode(s.jobs)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+    "context"
+    "net/http"
+
+    "go.opentelemetry.io/otel"
+    "go.opentelemetry.io/otel/attribute"
+    "go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+    "go.opentelemetry.io/otel/propagation"
+    "go.opentelemetry.io/otel/sdk/resource"
+    sdktrace "go.opentelemetry.io/otel/sdk/trace"
+    "go.opentelemetry.io/otel/trace"
+)
+
+// tracer emits spans across the HTTP handler -> job worker ->
+// filesystem-walk boundary so a single cleanup can be traced end to end.
+// It is a no-op until initTracing registers a real TracerProvider.
+var tracer = otel.Tracer("cleanup-service")
+
+// initTracing registers a TracerProvider and a W3C trace-context propagator
+// so the spans started via tracer are actually exported, and returns a
+// shutdown func that flushes and stops the exporter.
+func initTracing(serviceName string) (func(context.Context) error, error) {
+    exporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+    if err != nil {
+        return nil, err
+    }
+
+    tp := sdktrace.NewTracerProvider(
+        sdktrace.WithBatcher(exporter),
+        sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+    )
+    otel.SetTracerProvider(tp)
+    otel.SetTextMapPropagator(propagation.TraceContext{})
+
+    return tp.Shutdown, nil
+}
+
+// WithTraceContext extracts an incoming W3C traceparent (via the propagator
+// registered by initTracing) onto the request context, so spans started
+// downstream - in the handler and in the async job it enqueues - are
+// children of the caller's trace instead of roots of their own.
+func WithTraceContext(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+        next.ServeHTTP(w, r.WithContext(ctx))
+    })
+}
+
+// detachedRootContext carries only the trace context of parent across the
+// request/job boundary, since the request's own context is cancelled once
+// the handler returns but the job keeps running in the background.
+func detachedRootContext(parent context.Context) context.Context {
+    return trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(parent))
+}
+
+func jobSpanAttributes(spec CleanupSpec) []attribute.KeyValue {
+    return []attribute.KeyValue{
+        attribute.Bool("cleanup.dry_run", spec.DryRun),
+        attribute.Int("cleanup.dir_count", len(spec.Dirs)),
+    }
+}
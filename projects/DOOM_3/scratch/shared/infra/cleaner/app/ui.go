@@ -0,0 +1,50 @@
+package main
+
+import (
+    "embed"
+    "io/fs"
+    "net/http"
+)
+
+//go:embed static
+var embeddedStatic embed.FS
+
+// uiFS returns the filesystem backing /ui/. When staticDir is set (via
+// -static-dir) it serves from disk instead, so operators can iterate on the
+// UI without rebuilding the binary.
+func uiFS(staticDir string) (http.FileSystem, error) {
+    if staticDir != "" {
+        return http.Dir(staticDir), nil
+    }
+    sub, err := fs.Sub(embeddedStatic, "static")
+    if err != nil {
+        return nil, err
+    }
+    return http.FS(sub), nil
+}
+
+// mountUI wires /ui/ to the static asset server and /api/v1/* to the same
+// cleanup JSON API exposed at /cleanup, for the UI to call.
+func mountUI(mux *http.ServeMux, svc *CleanupService, staticFS http.FileSystem, authOnly Chain) {
+    fileServer := http.FileServer(staticFS)
+    mux.Handle("/ui/", http.StripPrefix("/ui/", fileServer))
+
+    apiMux := http.NewServeMux()
+    apiMux.HandleFunc("/api/v1/cleanup", func(w http.ResponseWriter, r *http.Request) {
+        switch r.Method {
+        case http.MethodPost:
+            svc.handleCleanupCreate(w, r)
+        case http.MethodGet:
+            svc.handleCleanupList(w, r)
+        default:
+            http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        }
+    })
+    apiMux.HandleFunc("/api/v1/cleanup/", func(w http.ResponseWriter, r *http.Request) {
+        r2 := r.Clone(r.Context())
+        r2.URL.Path = "/cleanup/" + r.URL.Path[len("/api/v1/cleanup/"):]
+        svc.handleCleanupItem(w, r2)
+    })
+    mux.Handle("/api/v1/cleanup", authOnly.Then(apiMux))
+    mux.Handle("/api/v1/cleanup/", authOnly.Then(apiMux))
+}